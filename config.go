@@ -0,0 +1,185 @@
+package main
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/viper"
+)
+
+// defaultPortfolio is the name LoadFundIds and the legacy ~/.fund migration
+// use for the portfolio that used to be the server's only one.
+const defaultPortfolio = "default"
+
+// FundMeta describes one holding within a Portfolio: its id and portfolio
+// weight, plus optional cost-basis and tagging metadata that isn't used for
+// fetching but is handy for callers building richer views.
+type FundMeta struct {
+	Id            string   `mapstructure:"id"`
+	Weight        float64  `mapstructure:"weight"`
+	PurchasePrice float64  `mapstructure:"purchasePrice"`
+	Shares        float64  `mapstructure:"shares"`
+	Tags          []string `mapstructure:"tags"`
+}
+
+func (f FundMeta) FundId() FundId {
+	return FundId{Id: f.Id, Weight: f.Weight}
+}
+
+// Portfolio is one named group of funds.
+type Portfolio struct {
+	Funds []FundMeta `mapstructure:"funds"`
+}
+
+// Config is the full ~/.fund.yaml document.
+type Config struct {
+	Provider     string               `mapstructure:"provider"`
+	Port         int                  `mapstructure:"port"`
+	PollInterval time.Duration        `mapstructure:"pollInterval"`
+	Portfolios   map[string]Portfolio `mapstructure:"portfolios"`
+}
+
+var (
+	configMu sync.RWMutex
+	config   Config
+)
+
+// currentConfig returns the most recently loaded config; safe to call
+// concurrently with a reload triggered by loadConfig's file watch.
+func currentConfig() Config {
+	configMu.RLock()
+	defer configMu.RUnlock()
+	return config
+}
+
+// fundIdsForPortfolio looks up a portfolio by name. viper lowercases every
+// map key on Unmarshal, so a portfolio named "Retirement" in ~/.fund.yaml
+// ends up keyed "retirement" in Config.Portfolios; lowercase name here so
+// the lookup still matches regardless of how it was cased in the request.
+func fundIdsForPortfolio(name string) []FundId {
+	portfolio, ok := currentConfig().Portfolios[strings.ToLower(name)]
+	if !ok {
+		return nil
+	}
+	ids := make([]FundId, len(portfolio.Funds))
+	for i, f := range portfolio.Funds {
+		ids[i] = f.FundId()
+	}
+	return ids
+}
+
+// loadConfig reads ~/.fund.yaml, auto-migrating a legacy ~/.fund CSV into it
+// on first run, then watches the file for edits so provider choice,
+// portfolios and pollInterval all take effect without a restart (poller.go's
+// StartPoller re-reads PollInterval every tick unless pinned by the -i flag).
+// port is bound once at startup and always requires a restart to change.
+func loadConfig() {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		home = os.Getenv("HOME")
+	}
+	configPath := filepath.Join(home, ".fund.yaml")
+	migrateLegacyFundFile(home, configPath)
+
+	v := viper.New()
+	v.SetConfigFile(configPath)
+	v.SetConfigType("yaml")
+	v.SetDefault("provider", "eastmoney")
+	v.SetDefault("port", 16000)
+	v.SetDefault("pollInterval", defaultPollInterval)
+
+	apply := func() {
+		var c Config
+		if err := v.Unmarshal(&c); err != nil {
+			log.Printf("failed to parse %s: %v", configPath, err)
+			return
+		}
+		configMu.Lock()
+		config = c
+		configMu.Unlock()
+		setProvider(providerFor(c.Provider))
+	}
+
+	if err := v.ReadInConfig(); err != nil {
+		if _, notFound := err.(viper.ConfigFileNotFoundError); !notFound {
+			log.Printf("failed to read %s: %v", configPath, err)
+		}
+	}
+	apply()
+
+	v.OnConfigChange(func(e fsnotify.Event) {
+		log.Printf("config changed, reloading: %s", e.Name)
+		apply()
+	})
+	v.WatchConfig()
+}
+
+func providerFor(name string) FundProvider {
+	switch name {
+	case "sina":
+		return SinaProvider{}
+	case "multi":
+		return MultiProvider{Providers: []FundProvider{EastmoneyProvider{}, SinaProvider{}}}
+	default:
+		return EastmoneyProvider{}
+	}
+}
+
+// migrateLegacyFundFile converts a pre-existing "id,weight" ~/.fund CSV into
+// the default portfolio of a new ~/.fund.yaml, if one doesn't already exist.
+// A malformed line is skipped with a warning rather than aborting the
+// migration, matching GetFundResult's report-don't-drop-everything style.
+func migrateLegacyFundFile(home, configPath string) {
+	if _, err := os.Stat(configPath); err == nil {
+		return
+	}
+	legacyPath := filepath.Join(home, ".fund")
+	data, err := os.ReadFile(legacyPath)
+	if err != nil {
+		return
+	}
+
+	var funds []map[string]interface{}
+	for _, line := range strings.Split(string(data), "\n") {
+		if line == "" {
+			continue
+		}
+		fields := strings.Split(line, ",")
+		if len(fields) < 2 {
+			log.Printf("skipping malformed line in %s: %q", legacyPath, line)
+			continue
+		}
+		weight, err := strconv.ParseFloat(strings.TrimSpace(fields[1]), 64)
+		if err != nil {
+			log.Printf("skipping malformed line in %s: %q", legacyPath, line)
+			continue
+		}
+		funds = append(funds, map[string]interface{}{
+			"id":     strings.TrimSpace(fields[0]),
+			"weight": weight,
+		})
+	}
+	if len(funds) == 0 {
+		return
+	}
+
+	v := viper.New()
+	v.SetConfigType("yaml")
+	v.Set("provider", "eastmoney")
+	v.Set("port", 16000)
+	v.Set("pollInterval", defaultPollInterval.String())
+	v.Set("portfolios", map[string]interface{}{
+		defaultPortfolio: map[string]interface{}{"funds": funds},
+	})
+	if err := v.WriteConfigAs(configPath); err != nil {
+		log.Printf("failed to migrate %s into %s: %v", legacyPath, configPath, err)
+		return
+	}
+	log.Printf("migrated legacy %s into %s", legacyPath, configPath)
+}
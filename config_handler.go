@@ -0,0 +1,38 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// portfoliosHandler lists the names of every configured portfolio.
+func portfoliosHandler(w http.ResponseWriter, r *http.Request) {
+	cfg := currentConfig()
+	names := make([]string, 0, len(cfg.Portfolios))
+	for name := range cfg.Portfolios {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(names)
+}
+
+// portfolioHandler fetches and returns the FundResult for the named
+// portfolio, e.g. GET /portfolio/retirement.
+func portfolioHandler(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, "/portfolio/")
+	if name == "" {
+		http.Error(w, "missing portfolio name", http.StatusBadRequest)
+		return
+	}
+	ids := fundIdsForPortfolio(name)
+	if ids == nil {
+		http.NotFound(w, r)
+		return
+	}
+	result := GetFundResult(r.Context(), ids)
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(result)
+}
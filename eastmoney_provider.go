@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// EastmoneyProvider fetches the estimated growth rate from Eastmoney's
+// mobile API. It's the default FundProvider.
+type EastmoneyProvider struct{}
+
+func (EastmoneyProvider) Fetch(ctx context.Context, id string) (Fund, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET",
+		"https://fundmobapi.eastmoney.com/FundMApi/FundVarietieValuationDetail.ashx",
+		nil)
+	if err != nil {
+		return Fund{}, err
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0 (iPhone; CPU iPhone OS 11_0 like Mac OS X) AppleWebKit/604.1.38 (KHTML, like Gecko) Version/11.0 Mobile/15A372 Safari/604.1")
+	req.Header.Set("Accept", "application/json")
+	q := req.URL.Query()
+	q.Add("FCODE", id)
+	q.Add("RANGE", "y")
+	q.Add("deviceid", "Wap")
+	q.Add("plat", "Wap")
+	q.Add("product", "EFund")
+	q.Add("version", "2.0.0")
+	ts := fmt.Sprintf("%d", time.Now().UnixNano()/1000)
+	q.Add("_", ts)
+	req.URL.RawQuery = q.Encode()
+
+	resp, err := doWithRetry(req)
+	if err != nil {
+		return Fund{}, fmt.Errorf("fetch fund %s from eastmoney: %w", id, err)
+	}
+	defer resp.Body.Close()
+	bytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return Fund{}, fmt.Errorf("read fund %s from eastmoney: %w", id, err)
+	}
+	wrapper := &FundWrapper{}
+	if err := json.Unmarshal(bytes, wrapper); err != nil {
+		return Fund{}, fmt.Errorf("decode fund %s from eastmoney: %w", id, err)
+	}
+	return wrapper.Fund, nil
+}
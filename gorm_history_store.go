@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+// historyRecord is the GORM model backing GormHistoryStore.
+type historyRecord struct {
+	ID        uint   `gorm:"primaryKey"`
+	FCODE     string `gorm:"index"`
+	GZTIME    string
+	GSZZL     string
+	Weight    float64
+	SampledAt time.Time `gorm:"index"`
+}
+
+// GormHistoryStore is the default HistoryStore, backed by SQLite via GORM
+// using glebarez/sqlite, a pure-Go driver that avoids the CGO requirement of
+// gorm.io/driver/sqlite's mattn/go-sqlite3. The dsn it's opened with is a
+// plain file path; any GORM dialect (e.g. Postgres) can be swapped in
+// without touching callers since they only see the HistoryStore interface.
+type GormHistoryStore struct {
+	db *gorm.DB
+}
+
+func NewGormHistoryStore(dsn string) (*GormHistoryStore, error) {
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{})
+	if err != nil {
+		return nil, fmt.Errorf("open history store %s: %w", dsn, err)
+	}
+	if err := db.AutoMigrate(&historyRecord{}); err != nil {
+		return nil, fmt.Errorf("migrate history store %s: %w", dsn, err)
+	}
+	return &GormHistoryStore{db: db}, nil
+}
+
+func (s *GormHistoryStore) Record(ctx context.Context, samples []HistorySample) error {
+	if len(samples) == 0 {
+		return nil
+	}
+	records := make([]historyRecord, len(samples))
+	for i, sample := range samples {
+		records[i] = historyRecord{
+			FCODE:     sample.FCODE,
+			GZTIME:    sample.GZTIME,
+			GSZZL:     sample.GSZZL,
+			Weight:    sample.Weight,
+			SampledAt: sample.SampledAt,
+		}
+	}
+	return s.db.WithContext(ctx).Create(&records).Error
+}
+
+func (s *GormHistoryStore) QueryFund(ctx context.Context, fcode string, from, to time.Time) ([]HistorySample, error) {
+	var records []historyRecord
+	err := s.db.WithContext(ctx).
+		Where("fcode = ? AND sampled_at BETWEEN ? AND ?", fcode, from, to).
+		Order("sampled_at").
+		Find(&records).Error
+	if err != nil {
+		return nil, err
+	}
+	return toHistorySamples(records), nil
+}
+
+func (s *GormHistoryStore) QueryAll(ctx context.Context, from, to time.Time) ([]HistorySample, error) {
+	var records []historyRecord
+	err := s.db.WithContext(ctx).
+		Where("sampled_at BETWEEN ? AND ?", from, to).
+		Order("sampled_at").
+		Find(&records).Error
+	if err != nil {
+		return nil, err
+	}
+	return toHistorySamples(records), nil
+}
+
+func toHistorySamples(records []historyRecord) []HistorySample {
+	samples := make([]HistorySample, len(records))
+	for i, r := range records {
+		samples[i] = HistorySample{
+			FCODE:     r.FCODE,
+			GZTIME:    r.GZTIME,
+			GSZZL:     r.GSZZL,
+			Weight:    r.Weight,
+			SampledAt: r.SampledAt,
+		}
+	}
+	return samples
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// HistorySample is one (FCODE, GZTIME, GSZZL) tuple sampled by the
+// background poller at SampledAt, along with the fund's portfolio weight at
+// the time so /portfolio/history can reconstruct the weighted average.
+type HistorySample struct {
+	FCODE     string
+	GZTIME    string
+	GSZZL     string
+	Weight    float64
+	SampledAt time.Time
+}
+
+// HistoryStore persists sampled fund estimates and serves them back as time
+// series. GormHistoryStore is the default (SQLite-backed) implementation;
+// swap in another by implementing this interface.
+type HistoryStore interface {
+	Record(ctx context.Context, samples []HistorySample) error
+	QueryFund(ctx context.Context, fcode string, from, to time.Time) ([]HistorySample, error)
+	QueryAll(ctx context.Context, from, to time.Time) ([]HistorySample, error)
+}
+
+// historyStore is nil until loadHistoryStore succeeds; handlers and the
+// poller treat a nil store as "history disabled".
+var historyStore HistoryStore
+
+// PortfolioPoint is one point of the weighted-average time series returned
+// by /portfolio/history.
+type PortfolioPoint struct {
+	SampledAt time.Time
+	Avg       float64
+}
+
+// weightedAvgSeries buckets samples by SampledAt and computes the
+// weight-adjusted average GSZZL for each bucket, matching FundResult.avg.
+func weightedAvgSeries(samples []HistorySample) []PortfolioPoint {
+	type bucket struct {
+		total       float64
+		totalWeight float64
+	}
+	buckets := make(map[time.Time]*bucket)
+	var order []time.Time
+	for _, s := range samples {
+		b, ok := buckets[s.SampledAt]
+		if !ok {
+			b = &bucket{}
+			buckets[s.SampledAt] = b
+			order = append(order, s.SampledAt)
+		}
+		item := FundItem{Fund: Fund{GSZZL: s.GSZZL}, Weight: s.Weight}
+		b.total += item.Zzl() * s.Weight
+		b.totalWeight += s.Weight
+	}
+	sort.Slice(order, func(i, j int) bool { return order[i].Before(order[j]) })
+
+	points := make([]PortfolioPoint, 0, len(order))
+	for _, t := range order {
+		b := buckets[t]
+		var avg float64
+		if b.totalWeight != 0 {
+			avg = b.total / b.totalWeight
+		}
+		points = append(points, PortfolioPoint{SampledAt: t, Avg: avg})
+	}
+	return points
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// loadHistoryStore opens the default SQLite-backed HistoryStore at
+// ~/.fund-watcher.db. If it can't be opened, history is disabled for this
+// run rather than failing startup.
+func loadHistoryStore() HistoryStore {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		home = os.Getenv("HOME")
+	}
+	dsn := filepath.Join(home, ".fund-watcher.db")
+	store, err := NewGormHistoryStore(dsn)
+	if err != nil {
+		log.Printf("history store disabled: %v", err)
+		return nil
+	}
+	return store
+}
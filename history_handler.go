@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// defaultHistoryWindow is how far back /fund/history and /portfolio/history
+// look when the request doesn't supply a "from".
+const defaultHistoryWindow = 24 * time.Hour
+
+// parseHistoryRange reads "from"/"to" as RFC3339 timestamps, defaulting to
+// [now-defaultHistoryWindow, now].
+func parseHistoryRange(r *http.Request) (from, to time.Time, err error) {
+	to = time.Now()
+	from = to.Add(-defaultHistoryWindow)
+	if v := r.URL.Query().Get("from"); v != "" {
+		if from, err = time.Parse(time.RFC3339, v); err != nil {
+			return
+		}
+	}
+	if v := r.URL.Query().Get("to"); v != "" {
+		if to, err = time.Parse(time.RFC3339, v); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func fundHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	if historyStore == nil {
+		http.Error(w, "history store not configured", http.StatusServiceUnavailable)
+		return
+	}
+	id := r.URL.Query().Get("id")
+	if id == "" {
+		http.Error(w, "missing id", http.StatusBadRequest)
+		return
+	}
+	from, to, err := parseHistoryRange(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	samples, err := historyStore.QueryFund(r.Context(), id, from, to)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(samples)
+}
+
+func portfolioHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	if historyStore == nil {
+		http.Error(w, "history store not configured", http.StatusServiceUnavailable)
+		return
+	}
+	from, to, err := parseHistoryRange(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	samples, err := historyStore.QueryAll(r.Context(), from, to)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(weightedAvgSeries(samples))
+}
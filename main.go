@@ -1,6 +1,8 @@
 package main
 
 import (
+	"context"
+	"crypto/sha1"
 	_ "embed"
 	"encoding/json"
 	"flag"
@@ -13,7 +15,6 @@ import (
 	"os"
 	"sort"
 	"strconv"
-	"strings"
 	"sync"
 	"time"
 )
@@ -21,6 +22,12 @@ import (
 //go:embed table.html
 var tableHtml string
 
+const defaultWorkers = 8
+
+// maxWorkers bounds how many funds are fetched from the upstream API at
+// once; overridable via the -w flag.
+var maxWorkers = defaultWorkers
+
 type FundId struct {
 	Id     string
 	Weight float64
@@ -40,17 +47,26 @@ type FundItem struct {
 	Weight float64
 }
 
+// FundFailure records a fund that could not be fetched, so the caller can
+// tell a missing row from a zero-valued one.
+type FundFailure struct {
+	Id    string
+	Error string
+}
+
 type FundResult struct {
-	Funds []FundItem
-	Avg   float64
+	Funds    []FundItem
+	Failures []FundFailure
+	Avg      float64
 }
 
-func NewFundResult(items []FundItem) FundResult {
+func NewFundResult(items []FundItem, failures []FundFailure) FundResult {
 	fundResult := FundResult{}
 	sort.Slice(items, func(i, j int) bool {
 		return items[i].Weight >= items[j].Weight
 	})
 	fundResult.Funds = items
+	fundResult.Failures = failures
 	fundResult.Avg = fundResult.avg()
 	return fundResult
 }
@@ -62,6 +78,9 @@ func (p FundResult) avg() float64 {
 		total = total + fund.Zzl()*fund.Weight
 		totalWeight = totalWeight + fund.Weight
 	}
+	if totalWeight == 0 {
+		return 0
+	}
 	return total / totalWeight
 }
 
@@ -70,12 +89,62 @@ func (p FundItem) Zzl() float64 {
 	return zzl
 }
 
+// gzTimeLayout is the timestamp format Eastmoney reports in GZTIME.
+const gzTimeLayout = "2006-01-02 15:04"
+
+// lastModified returns the most recent GZTIME across all funds in the
+// result, for use as an HTTP Last-Modified value.
+func (p FundResult) lastModified() time.Time {
+	var latest time.Time
+	for _, fund := range p.Funds {
+		if t, err := time.ParseInLocation(gzTimeLayout, fund.GZTIME, time.Local); err == nil && t.After(latest) {
+			latest = t
+		}
+	}
+	return latest
+}
+
+// etag derives a weak ETag from every fund's id and GZTIME, so it only
+// changes when the underlying estimate actually refreshes.
+func (p FundResult) etag() string {
+	h := sha1.New()
+	for _, fund := range p.Funds {
+		_, _ = io.WriteString(h, fund.FCODE)
+		_, _ = io.WriteString(h, fund.GZTIME)
+	}
+	return fmt.Sprintf(`W/"%x"`, h.Sum(nil))
+}
+
 func main() {
 	isServer := flag.Bool("s", false, "server mode")
-	port := flag.Int("p", 16000, "port number, only valid in server mode")
+	port := flag.Int("p", 0, "port number, only valid in server mode; overrides config")
+	workers := flag.Int("w", 0, "max concurrent fund fetch workers")
+	pollInterval := flag.Duration("i", 0, "background poll interval, only valid in server mode; overrides config")
 	flag.Parse()
+
+	loadConfig()
+	if *workers > 0 {
+		maxWorkers = *workers
+	}
+	historyStore = loadHistoryStore()
+
 	if *isServer {
-		WebServer(*port)
+		cfg := currentConfig()
+		p := cfg.Port
+		if *port > 0 {
+			p = *port
+		}
+		if p == 0 {
+			p = 16000
+		}
+		interval := cfg.PollInterval
+		if *pollInterval > 0 {
+			interval = *pollInterval
+		}
+		if interval <= 0 {
+			interval = defaultPollInterval
+		}
+		WebServer(p, interval, *pollInterval)
 	} else {
 		Console()
 	}
@@ -83,7 +152,7 @@ func main() {
 
 func Console() {
 	ids := LoadFundIds()
-	fundResult := GetFundResult(ids)
+	fundResult := GetFundResult(context.Background(), ids)
 	t := table.NewWriter()
 	for _, fund := range fundResult.Funds {
 		t.AppendRow([]interface{}{fund.FCODE, fund.SHORTNAME, fund.GZTIME, fund.GSZZL, fund.Weight})
@@ -93,107 +162,141 @@ func Console() {
 	avg := fmt.Sprintf("%f%s", fundResult.avg(), "%")
 	t.AppendFooter(table.Row{"Avg", "", "", avg, ""})
 	t.Render()
+	for _, failure := range fundResult.Failures {
+		log.Printf("failed to fetch fund %s: %s", failure.Id, failure.Error)
+	}
 }
 
-func WebServer(port int) {
+// WebServer starts the background poller and serves the HTTP/WebSocket
+// endpoints on port. pollInterval is the ticker's starting interval;
+// pollIntervalOverride is the -i flag's value (0 if unset), which, if set,
+// pins the interval and opts it out of config.go's hot-reload. port itself
+// is bound once here and can't be changed without a restart.
+func WebServer(port int, pollInterval, pollIntervalOverride time.Duration) {
+	StartPoller(context.Background(), pollInterval, pollIntervalOverride)
 	http.HandleFunc("/fund", handler)
 	http.HandleFunc("/fund.html", htmlHandler)
+	http.HandleFunc("/fund/ws", wsHandler)
+	http.HandleFunc("/fund/history", fundHistoryHandler)
+	http.HandleFunc("/portfolio/history", portfolioHistoryHandler)
+	http.HandleFunc("/portfolios", portfoliosHandler)
+	http.HandleFunc("/portfolio/", portfolioHandler)
 	addr := fmt.Sprintf(":%d", port)
 	log.Fatal(http.ListenAndServe(addr, nil))
 }
 
 func handler(w http.ResponseWriter, r *http.Request) {
 	result := getResult(r)
+	if notModified(w, r, result) {
+		return
+	}
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(result)
 }
 
 func htmlHandler(w http.ResponseWriter, r *http.Request) {
 	result := getResult(r)
+	if notModified(w, r, result) {
+		return
+	}
 	tmpl, _ := template.New("h").Parse(tableHtml)
 	_ = tmpl.Execute(w, result)
 }
 
+// getResult returns the cached, periodically-refreshed FundResult for the
+// server's configured portfolio. Only when that portfolio is empty or missing
+// is a custom list of fund ids read from the request body instead; it's then
+// fetched live and bypasses the cache.
 func getResult(r *http.Request) FundResult {
 	ids := LoadFundIds()
 	if len(ids) == 0 {
-		_ = json.NewDecoder(r.Body).Decode(&ids)
+		var custom []FundId
+		_ = json.NewDecoder(r.Body).Decode(&custom)
+		log.Printf("%v", custom)
+		return GetFundResult(r.Context(), custom)
+	}
+	if cached, ok := loadCache(); ok {
+		return cached
 	}
-	log.Printf("%v", ids)
-	result := GetFundResult(ids)
-	return result
+	return GetFundResult(r.Context(), ids)
 }
 
-func LoadFundIds() []FundId {
-	home := os.Getenv("HOME")
-	file := home + "/.fund"
-	bytes, _ := os.ReadFile(file)
-	log.Printf("Load fund list from: %s", file)
-	s := string(bytes)
-	split := strings.Split(s, "\n")
-	var fundIds []FundId
-	for _, v := range split {
-		if v == "" {
-			continue
-		}
-		i := strings.Split(v, ",")
-		weight, _ := strconv.ParseFloat(i[1], 64)
-		x := FundId{i[0], weight}
-		fundIds = append(fundIds, x)
+// notModified sets ETag/Last-Modified cache headers for result and, if the
+// request's If-None-Match matches, writes a 304 and returns true.
+func notModified(w http.ResponseWriter, r *http.Request, result FundResult) bool {
+	etag := result.etag()
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Last-Modified", result.lastModified().UTC().Format(http.TimeFormat))
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return true
 	}
-	return fundIds
+	return false
+}
+
+// LoadFundIds returns the ids+weights of the default portfolio from the
+// hot-reloaded config.
+func LoadFundIds() []FundId {
+	return fundIdsForPortfolio(defaultPortfolio)
+}
+
+// fundOutcome carries either a successfully fetched FundItem or the error
+// that prevented it, tagged with the originating fund id.
+type fundOutcome struct {
+	Item FundItem
+	Id   string
+	Err  error
 }
-func GetFundResult(fundIds []FundId) FundResult {
+
+// GetFundResult fetches every fund in fundIds through a bounded pool of
+// maxWorkers workers. ctx is propagated to every upstream call, so
+// cancelling it (e.g. the client disconnecting) aborts in-flight fetches.
+// Funds that fail to fetch are reported in FundResult.Failures rather than
+// dropped, and the average is computed only over the successful ones.
+func GetFundResult(ctx context.Context, fundIds []FundId) FundResult {
+	jobs := make(chan FundId)
+	outcomes := make(chan fundOutcome)
+
 	var workerGroup sync.WaitGroup
-	var consumerGroup sync.WaitGroup
-	fundChan := make(chan FundItem)
-	workerGroup.Add(len(fundIds))
-	consumerGroup.Add(1)
-	items := make([]FundItem, 0)
-	for _, idAndWeight := range fundIds {
-		go GetFundRoutine(idAndWeight.Id, idAndWeight.Weight, &workerGroup, fundChan)
+	workers := maxWorkers
+	if workers <= 0 {
+		workers = defaultWorkers
+	}
+	workerGroup.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer workerGroup.Done()
+			for job := range jobs {
+				fund, err := currentProvider().Fetch(ctx, job.Id)
+				outcomes <- fundOutcome{FundItem{fund, job.Weight}, job.Id, err}
+			}
+		}()
 	}
+
 	go func() {
-		for fund := range fundChan {
-			items = append(items, fund)
+		defer close(jobs)
+		for _, id := range fundIds {
+			select {
+			case jobs <- id:
+			case <-ctx.Done():
+				return
+			}
 		}
-		consumerGroup.Done()
 	}()
-	workerGroup.Wait()
-	close(fundChan)
-	consumerGroup.Wait()
-	return NewFundResult(items)
-}
-
-func GetFundRoutine(id string, weight float64, wg *sync.WaitGroup, ch chan FundItem) {
-	defer wg.Done()
-	fund := GetFund(id)
-	item := FundItem{fund, weight}
-	ch <- item
-}
-
-func GetFund(id string) Fund {
-	req, _ := http.NewRequest("GET",
-		"https://fundmobapi.eastmoney.com/FundMApi/FundVarietieValuationDetail.ashx",
-		nil)
-	req.Header.Set("User-Agent", "Mozilla/5.0 (iPhone; CPU iPhone OS 11_0 like Mac OS X) AppleWebKit/604.1.38 (KHTML, like Gecko) Version/11.0 Mobile/15A372 Safari/604.1")
-	req.Header.Set("Accept", "application/json")
-	q := req.URL.Query()
-	q.Add("FCODE", id)
-	q.Add("RANGE", "y")
-	q.Add("deviceid", "Wap")
-	q.Add("plat", "Wap")
-	q.Add("product", "EFund")
-	q.Add("version", "2.0.0")
-	ts := fmt.Sprintf("%d", time.Now().UnixNano()/1000)
-	q.Add("_", ts)
-	req.URL.RawQuery = q.Encode()
-	resp, _ := http.DefaultClient.Do(req)
-	bytes, _ := io.ReadAll(resp.Body)
-	wrapper := &FundWrapper{}
-	e := json.Unmarshal(bytes, wrapper)
-	if e != nil {
-		log.Fatal(e)
-	}
-	return wrapper.Fund
+
+	go func() {
+		workerGroup.Wait()
+		close(outcomes)
+	}()
+
+	items := make([]FundItem, 0)
+	var failures []FundFailure
+	for o := range outcomes {
+		if o.Err != nil {
+			failures = append(failures, FundFailure{Id: o.Id, Error: o.Err.Error()})
+			continue
+		}
+		items = append(items, o.Item)
+	}
+	return NewFundResult(items, failures)
 }
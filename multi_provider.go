@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"fmt"
+)
+
+// MultiProvider queries its Providers for a fund and returns the first
+// successful result. By default it tries them in order, falling through on
+// error; set Parallel to race them instead, e.g. when every provider is
+// equally likely to be slow and latency matters more than upstream load.
+type MultiProvider struct {
+	Providers []FundProvider
+	Parallel  bool
+}
+
+func (m MultiProvider) Fetch(ctx context.Context, id string) (Fund, error) {
+	if m.Parallel {
+		return m.fetchParallel(ctx, id)
+	}
+	var lastErr error
+	for _, p := range m.Providers {
+		fund, err := p.Fetch(ctx, id)
+		if err == nil {
+			return fund, nil
+		}
+		lastErr = err
+	}
+	return Fund{}, fmt.Errorf("all providers failed for fund %s: %w", id, lastErr)
+}
+
+func (m MultiProvider) fetchParallel(ctx context.Context, id string) (Fund, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type outcome struct {
+		fund Fund
+		err  error
+	}
+	results := make(chan outcome, len(m.Providers))
+	for _, p := range m.Providers {
+		p := p
+		go func() {
+			fund, err := p.Fetch(ctx, id)
+			results <- outcome{fund, err}
+		}()
+	}
+
+	var lastErr error
+	for range m.Providers {
+		o := <-results
+		if o.err == nil {
+			return o.fund, nil
+		}
+		lastErr = o.err
+	}
+	return Fund{}, fmt.Errorf("all providers failed for fund %s: %w", id, lastErr)
+}
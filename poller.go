@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"log"
+	"sync/atomic"
+	"time"
+)
+
+// defaultPollInterval is how often the background poller refreshes the
+// cache when neither -i nor config's pollInterval sets one.
+const defaultPollInterval = 10 * time.Second
+
+// fundCache holds the most recently refreshed FundResult, swapped in
+// atomically so handlers never block on a fetch in progress.
+var fundCache atomic.Value
+
+func loadCache() (FundResult, bool) {
+	v := fundCache.Load()
+	if v == nil {
+		return FundResult{}, false
+	}
+	return v.(FundResult), true
+}
+
+func storeCache(result FundResult) {
+	fundCache.Store(result)
+}
+
+// StartPoller refreshes the cache for the default portfolio every interval
+// until ctx is cancelled, fetching once immediately so the cache is warm
+// before the first request. The portfolio is re-read from config on every
+// tick, so editing ~/.fund.yaml takes effect without a restart. Subscribed
+// WebSocket clients are pushed a new frame whenever the refreshed Avg
+// differs from the cached one.
+//
+// interval is the ticker's starting value. If intervalOverride is 0 (the -i
+// flag was unset), the ticker also re-reads config.go's hot-reloaded
+// pollInterval on every tick and resets itself when it changes; a nonzero
+// intervalOverride instead pins the interval for the life of the process.
+func StartPoller(ctx context.Context, interval, intervalOverride time.Duration) {
+	refresh := func() {
+		ids := LoadFundIds()
+		if len(ids) == 0 {
+			return
+		}
+		prev, hadPrev := loadCache()
+		result := GetFundResult(ctx, ids)
+		storeCache(result)
+		recordHistory(ctx, result)
+		if !hadPrev || result.Avg != prev.Avg {
+			hub.broadcast(WsFrame{Action: "update", Data: result})
+		}
+	}
+	refresh()
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		current := interval
+		for {
+			select {
+			case <-ticker.C:
+				refresh()
+				if next := effectivePollInterval(current, intervalOverride); next != current {
+					current = next
+					ticker.Reset(current)
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// effectivePollInterval returns the interval the poller should use on its
+// next tick: intervalOverride if set (the -i flag pins it), otherwise
+// config.go's hot-reloaded PollInterval, falling back to current if that's
+// unset.
+func effectivePollInterval(current, intervalOverride time.Duration) time.Duration {
+	if intervalOverride > 0 {
+		return intervalOverride
+	}
+	if cfg := currentConfig().PollInterval; cfg > 0 {
+		return cfg
+	}
+	return current
+}
+
+// recordHistory persists one sample per fund in result, tagged with the
+// current time, to historyStore. It's a no-op when history is disabled.
+func recordHistory(ctx context.Context, result FundResult) {
+	if historyStore == nil {
+		return
+	}
+	now := time.Now()
+	samples := make([]HistorySample, len(result.Funds))
+	for i, item := range result.Funds {
+		samples[i] = HistorySample{
+			FCODE:     item.FCODE,
+			GZTIME:    item.GZTIME,
+			GSZZL:     item.GSZZL,
+			Weight:    item.Weight,
+			SampledAt: now,
+		}
+	}
+	if err := historyStore.Record(ctx, samples); err != nil {
+		log.Printf("failed to record fund history: %v", err)
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	maxRetries   = 3
+	baseBackoff  = 200 * time.Millisecond
+	fetchTimeout = 8 * time.Second
+)
+
+var httpClient = &http.Client{
+	Timeout: fetchTimeout,
+	Transport: &http.Transport{
+		MaxIdleConns:        100,
+		MaxIdleConnsPerHost: 10,
+		IdleConnTimeout:     90 * time.Second,
+	},
+}
+
+// FundProvider fetches a single fund's current estimate from some upstream
+// data source. Implementations should honour ctx cancellation.
+type FundProvider interface {
+	Fetch(ctx context.Context, id string) (Fund, error)
+}
+
+// providerValue holds the FundProvider GetFundResult fetches through,
+// swapped in atomically: config.go's hot-reload watcher writes it from its
+// own goroutine on every ~/.fund.yaml change, while worker goroutines read
+// it concurrently via currentProvider.
+var providerValue atomic.Value
+
+func init() {
+	providerValue.Store(FundProvider(EastmoneyProvider{}))
+}
+
+func currentProvider() FundProvider {
+	return providerValue.Load().(FundProvider)
+}
+
+func setProvider(p FundProvider) {
+	providerValue.Store(p)
+}
+
+// doWithRetry performs req, retrying network errors and 5xx responses with
+// exponential backoff and jitter up to maxRetries times. It gives up early
+// if req's context is cancelled.
+func doWithRetry(req *http.Request) (*http.Response, error) {
+	var lastErr error
+	for attempt := 0; attempt <= maxRetries; attempt++ {
+		if attempt > 0 {
+			backoff := baseBackoff * time.Duration(1<<uint(attempt-1))
+			wait := backoff/2 + time.Duration(rand.Int63n(int64(backoff)))
+			select {
+			case <-time.After(wait):
+			case <-req.Context().Done():
+				return nil, req.Context().Err()
+			}
+		}
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if resp.StatusCode >= 500 {
+			_ = resp.Body.Close()
+			lastErr = fmt.Errorf("upstream returned %s", resp.Status)
+			continue
+		}
+		return resp, nil
+	}
+	return nil, lastErr
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// SinaProvider fetches the realtime fund estimate from Sina's quote feed,
+// for use as a fallback when Eastmoney is unavailable.
+type SinaProvider struct{}
+
+func (SinaProvider) Fetch(ctx context.Context, id string) (Fund, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET",
+		fmt.Sprintf("https://hq.sinajs.cn/list=f_%s", id), nil)
+	if err != nil {
+		return Fund{}, err
+	}
+	req.Header.Set("Referer", "https://finance.sina.com.cn")
+
+	resp, err := doWithRetry(req)
+	if err != nil {
+		return Fund{}, fmt.Errorf("fetch fund %s from sina: %w", id, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return Fund{}, fmt.Errorf("read fund %s from sina: %w", id, err)
+	}
+	return parseSinaFundLine(id, string(body))
+}
+
+// parseSinaFundLine parses a response line of the form
+// var hq_str_f_519066="大成300等权重指数A,2021-06-01,1.2345,15:00:00,1.2200,...";
+// into a Fund. Unlike Eastmoney, Sina's feed reports the estimated NAV and
+// the previous close rather than a ready-made growth-rate percentage, so
+// GSZZL is derived from the two rather than taken from a single field.
+func parseSinaFundLine(id, line string) (Fund, error) {
+	start := strings.IndexByte(line, '"')
+	end := strings.LastIndexByte(line, '"')
+	if start < 0 || end <= start {
+		return Fund{}, fmt.Errorf("unexpected sina response for fund %s: %q", id, line)
+	}
+	fields := strings.Split(line[start+1:end], ",")
+	if len(fields) < 5 {
+		return Fund{}, fmt.Errorf("unexpected sina field count for fund %s: %q", id, line)
+	}
+	estimate, err := strconv.ParseFloat(fields[2], 64)
+	if err != nil {
+		return Fund{}, fmt.Errorf("unexpected sina estimate nav for fund %s: %q", id, fields[2])
+	}
+	prevClose, err := strconv.ParseFloat(fields[4], 64)
+	if err != nil || prevClose == 0 {
+		return Fund{}, fmt.Errorf("unexpected sina previous close for fund %s: %q", id, fields[4])
+	}
+	gszzl := (estimate - prevClose) / prevClose * 100
+	return Fund{
+		FCODE:     id,
+		SHORTNAME: fields[0],
+		GZTIME:    fields[1] + " " + sinaTimeToMinute(fields[3]),
+		GSZZL:     strconv.FormatFloat(gszzl, 'f', 2, 64),
+	}, nil
+}
+
+// sinaTimeToMinute truncates a Sina "HH:MM:SS" time to "HH:MM", matching the
+// minute precision of gzTimeLayout so GZTIME stays parseable by lastModified.
+func sinaTimeToMinute(t string) string {
+	if i := strings.LastIndexByte(t, ':'); i >= 0 {
+		return t[:i]
+	}
+	return t
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"sync"
+
+	"github.com/gorilla/websocket"
+)
+
+// WsFrame is the JSON envelope exchanged over /fund/ws, both ways. Server
+// pushes currently only use action "update"; Params/Action on incoming
+// frames are reserved for future client requests (e.g. "subscribe" a
+// specific fund id).
+type WsFrame struct {
+	Action string      `json:"action"`
+	Params interface{} `json:"params,omitempty"`
+	Data   interface{} `json:"data,omitempty"`
+}
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	CheckOrigin:     func(r *http.Request) bool { return true },
+}
+
+// wsClient wraps a connection with the write mutex gorilla/websocket
+// requires: it allows at most one concurrent writer, so every write to conn
+// (the initial frame, a broadcast) must go through writeJSON.
+type wsClient struct {
+	conn *websocket.Conn
+	mu   sync.Mutex
+}
+
+func (c *wsClient) writeJSON(v interface{}) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.conn.WriteJSON(v)
+}
+
+func (c *wsClient) close() error {
+	return c.conn.Close()
+}
+
+// wsHub fans a WsFrame out to every connected browser.
+type wsHub struct {
+	mu      sync.Mutex
+	clients map[*wsClient]struct{}
+}
+
+var hub = &wsHub{clients: make(map[*wsClient]struct{})}
+
+func (h *wsHub) add(c *wsClient) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.clients[c] = struct{}{}
+}
+
+func (h *wsHub) remove(c *wsClient) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.clients[c]; !ok {
+		return
+	}
+	delete(h.clients, c)
+	_ = c.close()
+}
+
+func (h *wsHub) broadcast(frame WsFrame) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for c := range h.clients {
+		if err := c.writeJSON(frame); err != nil {
+			log.Printf("ws broadcast failed, dropping client: %v", err)
+			delete(h.clients, c)
+			_ = c.close()
+		}
+	}
+}
+
+// wsHandler upgrades /fund/ws, sends the current cache as the first frame,
+// then keeps the connection registered with hub until the browser
+// disconnects.
+func wsHandler(w http.ResponseWriter, r *http.Request) {
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Printf("ws upgrade failed: %v", err)
+		return
+	}
+	client := &wsClient{conn: conn}
+	hub.add(client)
+	defer hub.remove(client)
+
+	if cached, ok := loadCache(); ok {
+		if err := client.writeJSON(WsFrame{Action: "update", Data: cached}); err != nil {
+			return
+		}
+	}
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			return
+		}
+	}
+}